@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Position identifies a location in Modelfile source, all 1-indexed
+// except Offset.
+type Position struct {
+	Line, Column, Offset int
+}
+
+// Node is implemented by every element of a parsed File: directives,
+// the comments attached to them, and any other raw line (blank lines,
+// for instance) that Format must still be able to reproduce.
+type Node interface {
+	// Text renders the node back to Modelfile source, newline
+	// included. For a node parsed by ParseTree and never mutated,
+	// Text returns the exact bytes it was parsed from, so an
+	// unmutated File.Format round-trips byte-identically.
+	Text() string
+	Pos() Position
+}
+
+// CommentNode is a "#"-prefixed line. ParseTree preserves these
+// instead of discarding them the way Parse does.
+type CommentNode struct {
+	Raw      string
+	Position Position
+}
+
+func (n *CommentNode) Text() string  { return n.Raw }
+func (n *CommentNode) Pos() Position { return n.Position }
+
+// RawNode is any source line that is neither a comment nor a
+// directive — currently just blank lines — kept so Format can
+// reproduce them.
+type RawNode struct {
+	Raw      string
+	Position Position
+}
+
+func (n *RawNode) Text() string  { return n.Raw }
+func (n *RawNode) Pos() Position { return n.Position }
+
+// DirectiveNode is a single FROM, LICENSE, TEMPLATE, SYSTEM, ADAPTER,
+// PARAMETER, or MESSAGE directive. Name and Args mirror Command.
+type DirectiveNode struct {
+	Name     string
+	Args     string
+	Raw      string
+	Position Position
+
+	// Leading holds the comment lines immediately preceding this
+	// directive, so a Walk visitor can move or drop a directive
+	// together with the comments that document it.
+	Leading []*CommentNode
+}
+
+func (n *DirectiveNode) Text() string {
+	if n.Raw != "" {
+		return n.Raw
+	}
+	return Format([]Command{{Name: n.Name, Args: n.Args}})
+}
+
+func (n *DirectiveNode) Pos() Position { return n.Position }
+
+// File is the root of a parsed Modelfile's AST.
+type File struct {
+	Nodes []Node
+}
+
+// Format renders file back to Modelfile source.
+func (f *File) Format() string {
+	var sb strings.Builder
+	for _, n := range f.Nodes {
+		sb.WriteString(n.Text())
+	}
+	return sb.String()
+}
+
+// Commands flattens file into the legacy []Command representation
+// Parse also produces: directives as-is, "#" comments as a Command
+// named "comment" (see Parse), and blank lines dropped, for callers
+// not yet migrated to the tree API.
+func (f *File) Commands() []Command {
+	var cmds []Command
+	for _, n := range f.Nodes {
+		switch n := n.(type) {
+		case *DirectiveNode:
+			cmds = append(cmds, Command{Name: n.Name, Args: n.Args})
+		case *CommentNode:
+			text := strings.TrimPrefix(n.Raw, "#")
+			text = strings.TrimSuffix(text, "\n")
+			text = strings.TrimSuffix(text, "\r")
+			cmds = append(cmds, Command{Name: "comment", Args: text})
+		}
+	}
+	return cmds
+}
+
+// Visitor is called by Walk for every node in a File, in source order.
+type Visitor func(Node)
+
+// Walk calls visit for every node in file, in source order.
+func Walk(file *File, visit Visitor) {
+	for _, n := range file.Nodes {
+		visit(n)
+	}
+}
+
+// ParseTree parses r into a File, preserving comments and raw source
+// text so that File.Format round-trips an unmutated parse byte for
+// byte. Unlike Parse, it does not require a "from" directive — a
+// caller composing a File from several fragments (see Resolver)
+// enforces that check once, on the fully assembled result.
+//
+// ParseTree only has to tell directives, comments, and blank lines
+// apart; once it knows a chunk of source is a directive, it hands
+// that chunk to parseWithOptions — the same rune-level state machine
+// behind Parse — so the two never disagree about what a line means,
+// and an invalid command or message role surfaces as the same
+// *ParseError Parse would return, at the right position in file.
+func ParseTree(r io.Reader) (*File, error) {
+	file := &File{}
+	var pending []*CommentNode
+
+	br := bufio.NewReader(r)
+	pos := Position{Line: 1, Column: 1, Offset: 0}
+
+	for {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			break
+		}
+
+		start := pos
+
+		trimmed := strings.TrimLeft(line, " \t")
+		switch {
+		case strings.TrimRight(trimmed, "\r\n") == "":
+			advance(&pos, line)
+			pending = nil
+			file.Nodes = append(file.Nodes, &RawNode{Raw: line, Position: start})
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			advance(&pos, line)
+			c := &CommentNode{Raw: line, Position: start}
+			pending = append(pending, c)
+			file.Nodes = append(file.Nodes, c)
+			continue
+		}
+
+		raw := line
+		// an opening """ with no matching close on this line; keep
+		// reading raw lines until one closes it, so a multi-line
+		// value reaches parseWithOptions intact.
+		for strings.Count(raw, `"""`) == 1 {
+			next, rerr := br.ReadString('\n')
+			raw += next
+			if rerr != nil {
+				break
+			}
+		}
+		advance(&pos, raw)
+
+		var options parseOptions
+		cmds, _, perr := parseWithOptions("", strings.NewReader(raw), &options)
+		if perr != nil {
+			return nil, offsetParseError(perr, start)
+		}
+
+		d := &DirectiveNode{Name: cmds[0].Name, Args: cmds[0].Args, Raw: raw, Position: start, Leading: pending}
+		pending = nil
+		file.Nodes = append(file.Nodes, d)
+	}
+
+	return file, nil
+}
+
+// advance moves pos past raw, tracking line/column/offset.
+func advance(pos *Position, raw string) {
+	for _, r := range raw {
+		pos.Offset++
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+}
+
+// offsetParseError rewrites err, a *ParseError from parsing a single
+// directive chunk in isolation (so positioned as if that chunk were
+// its own file starting at line 1), to be relative to start, the
+// chunk's actual position within the file ParseTree is parsing.
+func offsetParseError(err error, start Position) error {
+	pe, ok := err.(*ParseError)
+	if !ok {
+		return err
+	}
+
+	line := start.Line + pe.Line - 1
+	col := pe.Column
+	if pe.Line == 1 {
+		col = start.Column + pe.Column - 1
+	}
+
+	return newParseError("", Position{Line: line, Column: col, Offset: start.Offset + pe.Offset}, pe.Snippet, pe.Kind, pe.err, pe.msg)
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseTreeRoundTrip(t *testing.T) {
+	src := "# a license\nFROM llama2\n\n# describe the model\nSYSTEM \"\"\"\nmulti\nline\n\"\"\"\nPARAMETER temperature 0.7\n"
+
+	file, err := ParseTree(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseTree: %v", err)
+	}
+
+	if got := file.Format(); got != src {
+		t.Fatalf("unmutated round-trip mismatch:\ngot:  %q\nwant: %q", got, src)
+	}
+}
+
+func TestParseTreeMatchesParse(t *testing.T) {
+	src := "# a license\nFROM llama2\nSYSTEM \"\"\"\nmulti\nline\n\"\"\"\nPARAMETER temperature 0.7\n"
+
+	want, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	file, err := ParseTree(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseTree: %v", err)
+	}
+
+	got := file.Commands()
+	if len(got) != len(want) {
+		t.Fatalf("Commands() length = %d, want %d (got %#v, want %#v)", len(got), len(want), got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Commands()[%d] = %#v, want %#v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseTreeInvalidCommand(t *testing.T) {
+	_, err := ParseTree(strings.NewReader("FROM llama2\nPARAMTER temperature 0.7\n"))
+	if err == nil {
+		t.Fatal("expected an error for an unknown command")
+	}
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError", err)
+	}
+
+	if pe.Kind != KindUnknownCommand {
+		t.Fatalf("Kind = %v, want KindUnknownCommand", pe.Kind)
+	}
+
+	if pe.Line != 2 {
+		t.Fatalf("Line = %d, want 2", pe.Line)
+	}
+}
+
+func TestParseTreeLeadingComments(t *testing.T) {
+	file, err := ParseTree(strings.NewReader("# about the base model\nFROM llama2\n"))
+	if err != nil {
+		t.Fatalf("ParseTree: %v", err)
+	}
+
+	var from *DirectiveNode
+	for _, n := range file.Nodes {
+		if d, ok := n.(*DirectiveNode); ok {
+			from = d
+		}
+	}
+	if from == nil {
+		t.Fatal("no DirectiveNode found")
+	}
+
+	if len(from.Leading) != 1 || from.Leading[0].Raw != "# about the base model\n" {
+		t.Fatalf("Leading = %#v, want one comment", from.Leading)
+	}
+}
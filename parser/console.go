@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// parameterNames lists the PARAMETER names the console offers for tab
+// completion — the ones ollama's model runner recognizes.
+var parameterNames = []string{
+	"num_ctx", "num_predict", "num_gpu", "num_thread",
+	"temperature", "top_k", "top_p", "min_p",
+	"repeat_penalty", "repeat_last_n",
+	"mirostat", "mirostat_eta", "mirostat_tau",
+	"seed", "stop",
+}
+
+// completions lists every token the console knows how to tab-complete:
+// the fixed command set, the fixed message roles, and known parameter
+// names.
+var completions = dedupe(knownCommands, knownMessageRoles, parameterNames)
+
+// dedupe concatenates lists and removes duplicate entries, preserving
+// first-seen order.
+func dedupe(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, list := range lists {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// LineEditor reads a single line of input, optionally offering history
+// and tab-completion. Embedders that want real terminal line editing
+// (e.g. a chzyer/readline or liner session) can satisfy this interface
+// and pass it to NewConsole in place of the default, which falls back
+// to plain buffered reads with no editing support.
+type LineEditor interface {
+	// Readline returns the next line of input without its trailing
+	// newline, or io.EOF once the input is exhausted.
+	Readline() (string, error)
+}
+
+// scannerEditor is the default LineEditor, used when the console is
+// driven over a plain io.Reader such as a pipe or a test fixture.
+type scannerEditor struct {
+	scanner *bufio.Scanner
+}
+
+func (e *scannerEditor) Readline() (string, error) {
+	if !e.scanner.Scan() {
+		if err := e.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return e.scanner.Text(), nil
+}
+
+// Console is an interactive session for authoring a Modelfile one line
+// at a time. Each line is validated and echoed back in its canonical
+// form, so mistakes surface immediately instead of at the end of a
+// full Parse.
+type Console struct {
+	editor LineEditor
+	out    io.Writer
+
+	cmds []Command
+}
+
+// NewConsole creates a Console that reads lines from r and writes
+// prompts and echoes to w. Use NewConsoleWithEditor to plug in a
+// readline-style LineEditor for history and tab-completion.
+func NewConsole(r io.Reader, w io.Writer) *Console {
+	return NewConsoleWithEditor(&scannerEditor{scanner: bufio.NewScanner(r)}, w)
+}
+
+// NewConsoleWithEditor creates a Console driven by editor, which may
+// implement history and tab-completion (see Complete) on top of the
+// bare Readline contract.
+func NewConsoleWithEditor(editor LineEditor, w io.Writer) *Console {
+	return &Console{editor: editor, out: w}
+}
+
+// Complete returns the known commands, message roles, and parameter
+// names with the given prefix, sorted, for embedders wiring up
+// tab-completion against a LineEditor.
+func Complete(prefix string) []string {
+	var matches []string
+	for _, c := range completions {
+		if strings.HasPrefix(c, strings.ToLower(prefix)) {
+			matches = append(matches, c)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// Run drives the console until the input is exhausted, returning nil
+// at EOF or whatever I/O error the editor produced. A line that fails
+// to parse, or a console command that fails, is reported to out and
+// the session continues — Run only returns early on an editor error.
+// ":"-prefixed lines are interpreted as console commands; anything
+// else is parsed as a single Modelfile line.
+func (c *Console) Run() error {
+	for {
+		fmt.Fprint(c.out, "> ")
+
+		line, err := c.editor.Readline()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			if err := c.meta(line); err != nil {
+				fmt.Fprintln(c.out, err)
+			}
+			continue
+		}
+
+		cmd, err := parseLine(line)
+		if err != nil {
+			fmt.Fprintln(c.out, err)
+			continue
+		}
+
+		c.cmds = append(c.cmds, cmd)
+		fmt.Fprint(c.out, Format([]Command{cmd}))
+	}
+}
+
+// meta handles the ":show", ":undo", ":save <path>", and ":load <path>"
+// console commands.
+func (c *Console) meta(line string) error {
+	name, rest, _ := strings.Cut(line[1:], " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(name) {
+	case "show":
+		fmt.Fprint(c.out, Format(c.cmds))
+		return nil
+	case "undo":
+		if len(c.cmds) == 0 {
+			return nil
+		}
+		c.cmds = c.cmds[:len(c.cmds)-1]
+		return nil
+	case "save":
+		if rest == "" {
+			return fmt.Errorf(":save requires a path")
+		}
+		return os.WriteFile(rest, []byte(Format(c.cmds)), 0o644)
+	case "load":
+		if rest == "" {
+			return fmt.Errorf(":load requires a path")
+		}
+		f, err := os.Open(rest)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		cmds, err := Parse(f)
+		if err != nil {
+			return err
+		}
+		c.cmds = cmds
+		return nil
+	default:
+		return fmt.Errorf("unknown console command %q", name)
+	}
+}
+
+// Commands returns the commands accumulated so far in the session.
+func (c *Console) Commands() []Command {
+	return c.cmds
+}
+
+// parseLine parses a single Modelfile line in isolation by delegating
+// to Parse, which only requires that a "from" line be present
+// somewhere in the stream it's given; the console works around this
+// by parsing lines individually and relaxing that check itself.
+func parseLine(line string) (Command, error) {
+	cmds, err := parseNoFromCheck("", strings.NewReader(line+"\n"))
+	if err != nil {
+		return Command{}, err
+	}
+
+	if len(cmds) != 1 {
+		return Command{}, fmt.Errorf("expected a single command, got %d", len(cmds))
+	}
+
+	return cmds[0], nil
+}
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConsoleRunContinuesAfterBadLine(t *testing.T) {
+	in := strings.NewReader("PARAMTER temperature 0.7\nFROM llama2\n")
+	var out strings.Builder
+
+	c := NewConsole(in, &out)
+	if err := c.Run(); err != nil {
+		t.Fatalf("Run returned %v, want nil", err)
+	}
+
+	cmds := c.Commands()
+	if len(cmds) != 1 || cmds[0].Name != "model" {
+		t.Fatalf("Commands() = %#v, want a single FROM command", cmds)
+	}
+
+	if !strings.Contains(out.String(), "unknown command") {
+		t.Fatalf("output = %q, want the bad line's error reported", out.String())
+	}
+}
+
+func TestCompleteIncludesParameterNames(t *testing.T) {
+	matches := Complete("temperat")
+	if len(matches) != 1 || matches[0] != "temperature" {
+		t.Fatalf("Complete(%q) = %#v, want [%q]", "temperat", matches, "temperature")
+	}
+}
+
+func TestCompleteHasNoDuplicates(t *testing.T) {
+	matches := Complete("s")
+
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if seen[m] {
+			t.Fatalf("Complete(%q) = %#v, contains duplicate %q", "s", matches, m)
+		}
+		seen[m] = true
+	}
+
+	if !seen["system"] {
+		t.Fatalf("Complete(%q) = %#v, want it to include %q", "s", matches, "system")
+	}
+}
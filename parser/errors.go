@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies what went wrong during a Parse, for callers
+// that want to branch on the failure mode instead of matching error
+// text.
+type ErrorKind int
+
+const (
+	KindUnknownCommand ErrorKind = iota
+	KindInvalidMessageRole
+	KindUnexpectedEOF
+	KindMissingFrom
+	KindDuplicateFrom
+	KindIncludeUnresolved
+	KindIncludeError
+	KindIncludeCycle
+	KindIncludeDepth
+)
+
+// ParseError is returned by Parse, ParseFile, and ParseTree for any
+// failure that can be pinned to a source location. It wraps the
+// original sentinel error (errInvalidCommand, io.ErrUnexpectedEOF,
+// ...) so existing errors.Is checks keep working.
+type ParseError struct {
+	Name    string
+	Line    int
+	Column  int
+	Offset  int
+	Snippet string
+	Kind    ErrorKind
+
+	msg string
+	err error
+}
+
+func newParseError(name string, pos Position, snippet string, kind ErrorKind, sentinel error, msg string) *ParseError {
+	return &ParseError{
+		Name:    name,
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Offset:  pos.Offset,
+		Snippet: snippet,
+		Kind:    kind,
+		err:     sentinel,
+		msg:     msg,
+	}
+}
+
+func (e *ParseError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.Name, e.Line, e.Column, e.msg)
+	}
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Column, e.msg)
+}
+
+func (e *ParseError) Unwrap() error { return e.err }
+
+// Caret renders the offending source line followed by a line with a
+// caret ("^") under Column, for editor-style diagnostics.
+func (e *ParseError) Caret() string {
+	if e.Snippet == "" {
+		return ""
+	}
+
+	col := e.Column - 1
+	if col < 0 {
+		col = 0
+	}
+
+	return e.Snippet + "\n" + strings.Repeat(" ", col) + "^"
+}
+
+var knownCommands = []string{"from", "license", "template", "system", "adapter", "parameter", "message", "include"}
+
+var knownMessageRoles = []string{"system", "user", "assistant"}
+
+func unknownCommandMessage(got string) string {
+	msg := fmt.Sprintf("unknown command %q", got)
+	if s := suggest(got, knownCommands); s != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", s)
+	}
+	return msg
+}
+
+func invalidMessageRoleMessage(got string) string {
+	msg := fmt.Sprintf("invalid message role %q", got)
+	if s := suggest(got, knownMessageRoles); s != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", s)
+	}
+	return msg
+}
+
+// suggest returns the candidate closest to s by Levenshtein distance,
+// or "" if none is within a distance of 2 — close enough to be a
+// likely typo, far enough to avoid nonsense suggestions.
+func suggest(s string, candidates []string) string {
+	const maxDistance = 2
+
+	best, bestDist := "", maxDistance+1
+	for _, c := range candidates {
+		if d := levenshtein(strings.ToLower(s), c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+
+	if bestDist > maxDistance {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(curr[j-1]+1, minInt(prev[j]+1, prev[j-1]+cost))
+		}
+		prev = curr
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,49 @@
+package parser
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"system", "system", 0},
+		{"paramter", "parameter", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, c := range cases {
+		if got := levenshtein(c.a, c.b); got != c.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	if got := suggest("paramter", knownCommands); got != "parameter" {
+		t.Errorf("suggest(%q) = %q, want %q", "paramter", got, "parameter")
+	}
+}
+
+func TestSuggestNoneWithinRange(t *testing.T) {
+	if got := suggest("xyzzyplugh", knownCommands); got != "" {
+		t.Errorf("suggest(%q) = %q, want \"\"", "xyzzyplugh", got)
+	}
+}
+
+func TestUnknownCommandMessageIncludesSuggestion(t *testing.T) {
+	msg := unknownCommandMessage("paramter")
+	want := `unknown command "paramter" (did you mean "parameter"?)`
+	if msg != want {
+		t.Errorf("unknownCommandMessage(%q) = %q, want %q", "paramter", msg, want)
+	}
+}
+
+func TestInvalidMessageRoleMessageIncludesSuggestion(t *testing.T) {
+	msg := invalidMessageRoleMessage("asistant")
+	want := `invalid message role "asistant" (did you mean "assistant"?)`
+	if msg != want {
+		t.Errorf("invalidMessageRoleMessage(%q) = %q, want %q", "asistant", msg, want)
+	}
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// InterpContext carries the variables available to the {{ var "NAME" }}
+// template func during interpolation. It's supplied by the caller via
+// WithInterpolation, since the parser itself has no notion of a build
+// environment.
+type InterpContext struct {
+	Vars map[string]string
+}
+
+// ParseOption configures a single call to Parse.
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	interp   *InterpContext
+	resolver Resolver
+}
+
+// WithInterpolation enables template interpolation of quoted values
+// (LICENSE, TEMPLATE, SYSTEM, ADAPTER, MESSAGE, and PARAMETER args)
+// against ctx. Call sites that want the literal, uninterpolated text
+// — editors, linters, the Console — should omit this option.
+//
+// Unlike INCLUDE, the {{ file "path" }} func has no Resolver-style
+// sandboxing: it reads any path the process can see. Only enable
+// interpolation for Modelfiles from a source you trust.
+func WithInterpolation(ctx InterpContext) ParseOption {
+	return func(o *parseOptions) {
+		o.interp = &ctx
+	}
+}
+
+// interpolate evaluates s as a text/template against ctx, exposing
+// env, file, timestamp, and var funcs modeled on HashiCorp Packer's
+// template engine. A nil ctx is a no-op so callers that didn't pass
+// WithInterpolation never pay for a template parse.
+func interpolate(s string, ctx *InterpContext) (string, error) {
+	if ctx == nil || !strings.Contains(s, "{{") {
+		return s, nil
+	}
+
+	funcs := template.FuncMap{
+		"env": os.Getenv,
+		// file is intentionally unsandboxed, unlike INCLUDE's
+		// Resolver — see the caveat on WithInterpolation.
+		"file": func(path string) (string, error) {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+		"timestamp": func() string {
+			return time.Now().UTC().Format(time.RFC3339)
+		},
+		"var": func(name string) (string, error) {
+			v, ok := ctx.Vars[name]
+			if !ok {
+				return "", fmt.Errorf("undefined variable %q", name)
+			}
+			return v, nil
+		},
+	}
+
+	tmpl, err := template.New("value").Funcs(funcs).Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("interpolate: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		return "", fmt.Errorf("interpolate: %w", err)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInterpolateVar(t *testing.T) {
+	src := `FROM llama2
+SYSTEM """hello {{ var "name" }}"""
+`
+
+	cmds, err := Parse(strings.NewReader(src), WithInterpolation(InterpContext{Vars: map[string]string{"name": "world"}}))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var gotSystem bool
+	for _, cmd := range cmds {
+		if cmd.Name == "system" {
+			gotSystem = true
+			if cmd.Args != "hello world" {
+				t.Fatalf("SYSTEM args = %q, want %q", cmd.Args, "hello world")
+			}
+		}
+	}
+	if !gotSystem {
+		t.Fatal("no SYSTEM command in result")
+	}
+}
+
+func TestInterpolateUndefinedVar(t *testing.T) {
+	src := `FROM llama2
+SYSTEM """{{ var "missing" }}"""
+`
+
+	if _, err := Parse(strings.NewReader(src), WithInterpolation(InterpContext{})); err == nil {
+		t.Fatal("expected an error for an undefined variable")
+	}
+}
+
+func TestInterpolateSkippedWithoutOption(t *testing.T) {
+	src := `FROM llama2
+SYSTEM """{{ var "name" }}"""
+`
+
+	cmds, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Name == "system" && cmd.Args != `{{ var "name" }}` {
+			t.Fatalf("SYSTEM args = %q, want literal template text", cmd.Args)
+		}
+	}
+}
@@ -28,8 +28,9 @@ const (
 
 var (
 	errMissingFrom        = errors.New("no FROM line")
+	errDuplicateFrom      = errors.New("only one FROM line is allowed, in the root file")
 	errInvalidMessageRole = errors.New("message role must be one of \"system\", \"user\", or \"assistant\"")
-	errInvalidCommand     = errors.New("command must be one of \"from\", \"license\", \"template\", \"system\", \"adapter\", \"parameter\", or \"message\"")
+	errInvalidCommand     = errors.New("command must be one of \"from\", \"license\", \"template\", \"system\", \"adapter\", \"parameter\", \"message\", or \"include\"")
 )
 
 func Format(cmds []Command) string {
@@ -39,10 +40,13 @@ func Format(cmds []Command) string {
 		args := cmd.Args
 
 		switch cmd.Name {
+		case "comment":
+			fmt.Fprintln(&sb, "#"+cmd.Args)
+			continue
 		case "model":
 			name = "from"
 			args = cmd.Args
-		case "license", "template", "system", "adapter":
+		case "license", "template", "system", "adapter", "include":
 			args = quote(args)
 		case "message":
 			role, message, _ := strings.Cut(cmd.Args, ": ")
@@ -58,26 +62,137 @@ func Format(cmds []Command) string {
 	return sb.String()
 }
 
-func Parse(r io.Reader) (cmds []Command, err error) {
+// Parse parses a Modelfile into a flat list of Commands. It is the
+// legacy API kept for callers that only care about directives. A "#"
+// comment is preserved as a Command with Name "comment" and Args set
+// to the text following the "#", so Format(Parse(r)) reproduces them;
+// blank lines are still dropped, and there's no way to recover source
+// positions from the result. Callers that need blank lines or
+// positions preserved should use ParseTree instead.
+func Parse(r io.Reader, opts ...ParseOption) (cmds []Command, err error) {
+	return parse("", r, opts...)
+}
+
+// ParseFile is Parse with a filename attached, so a ParseError can
+// report e.g. "modelfile:7:14: ..." for editor integrations that need
+// to map a diagnostic back to a source file.
+func ParseFile(name string, r io.Reader, opts ...ParseOption) (cmds []Command, err error) {
+	return parse(name, r, opts...)
+}
+
+func parse(name string, r io.Reader, opts ...ParseOption) (cmds []Command, err error) {
+	var options parseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	cmds, positions, err := parseWithOptions(name, r, &options)
+	if err != nil {
+		return nil, err
+	}
+
+	// FROM may only come from the root file (expandIncludes rejects
+	// any INCLUDEd fragment that contributes its own), so a duplicate
+	// can only originate here, in name's own directly parsed commands.
+	if err := checkSingleFrom(name, cmds, positions); err != nil {
+		return nil, err
+	}
+
+	cmds, err = expandIncludes(cmds, positions, name, &options, []string{name}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Name == "model" {
+			return cmds, nil
+		}
+	}
+
+	return nil, newParseError(name, Position{Line: 1, Column: 1}, "", KindMissingFrom, errMissingFrom, errMissingFrom.Error())
+}
+
+// checkSingleFrom rejects a second FROM directive in cmds, pointing at
+// its position.
+func checkSingleFrom(name string, cmds []Command, positions []Position) error {
+	var seen bool
+	for i, cmd := range cmds {
+		if cmd.Name != "model" {
+			continue
+		}
+		if seen {
+			return newParseError(name, positions[i], "", KindDuplicateFrom, errDuplicateFrom, errDuplicateFrom.Error())
+		}
+		seen = true
+	}
+	return nil
+}
+
+// parseNoFromCheck runs the state machine over r and returns the
+// resulting commands without requiring a "from" line, so callers that
+// assemble a file from several partial sources (see Console) can
+// enforce that check only once, on the fully assembled result. Unlike
+// parse, it does not expand INCLUDE directives — the console parses
+// one line at a time, where an include can't resolve anyway.
+func parseNoFromCheck(name string, r io.Reader, opts ...ParseOption) (cmds []Command, err error) {
+	var options parseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	cmds, _, err = parseWithOptions(name, r, &options)
+	return cmds, err
+}
+
+// parseWithOptions is the state machine at the core of Parse; name is
+// used only to annotate ParseError locations. positions[i] is the
+// source position of the directive keyword that produced cmds[i], so
+// callers like expandIncludes can point a later error (an unresolved
+// INCLUDE, say) back at the line that caused it.
+func parseWithOptions(name string, r io.Reader, options *parseOptions) (cmds []Command, positions []Position, err error) {
 	var cmd Command
 	var curr state
 	var b bytes.Buffer
 	var role string
 
+	pos := Position{Line: 1, Column: 1}
+	var lineBuf strings.Builder
+	var nameStart, roleStart, commentStart Position
+
 	br := bufio.NewReader(r)
 	for {
 		r, _, err := br.ReadRune()
 		if errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
+		curPos := pos
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+			lineBuf.Reset()
+		} else {
+			pos.Column++
+			lineBuf.WriteRune(r)
+		}
+		pos.Offset++
+
 		next, r, err := parseRuneForState(r, curr)
 		if errors.Is(err, io.ErrUnexpectedEOF) {
-			return nil, fmt.Errorf("%w: %s", err, b.String())
+			return nil, nil, newParseError(name, curPos, lineBuf.String(), KindUnexpectedEOF, err, fmt.Sprintf("%s: %s", err, b.String()))
+		} else if errors.Is(err, errInvalidCommand) {
+			return nil, nil, newParseError(name, nameStart, lineBuf.String(), KindUnknownCommand, err, unknownCommandMessage(b.String()))
 		} else if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		if curr == stateNil && next == stateName {
+			nameStart = curPos
+		}
+		if curr == stateNil && next == stateComment {
+			commentStart = curPos
 		}
 
 		// process the state transition, some transitions need to be intercepted and redirected
@@ -85,7 +200,7 @@ func Parse(r io.Reader) (cmds []Command, err error) {
 			switch curr {
 			case stateName:
 				if !isValidCommand(b.String()) {
-					return nil, errInvalidCommand
+					return nil, nil, newParseError(name, nameStart, lineBuf.String(), KindUnknownCommand, errInvalidCommand, unknownCommandMessage(b.String()))
 				}
 
 				// next state sometimes depends on the current buffer value
@@ -98,6 +213,7 @@ func Parse(r io.Reader) (cmds []Command, err error) {
 				case "message":
 					// transition to stateMessage which validates the message role
 					next = stateMessage
+					roleStart = curPos
 					fallthrough
 				default:
 					cmd.Name = s
@@ -106,22 +222,30 @@ func Parse(r io.Reader) (cmds []Command, err error) {
 				cmd.Name = b.String()
 			case stateMessage:
 				if !isValidMessageRole(b.String()) {
-					return nil, errInvalidMessageRole
+					return nil, nil, newParseError(name, roleStart, lineBuf.String(), KindInvalidMessageRole, errInvalidMessageRole, invalidMessageRoleMessage(b.String()))
 				}
 
 				role = b.String()
-			case stateComment, stateNil:
+			case stateComment:
+				cmds = append(cmds, Command{Name: "comment", Args: b.String()})
+				positions = append(positions, commentStart)
+			case stateNil:
 				// pass
 			case stateValue:
 				s, ok := unquote(b.String())
 				if !ok || isSpace(r) {
 					if _, err := b.WriteRune(r); err != nil {
-						return nil, err
+						return nil, nil, err
 					}
 
 					continue
 				}
 
+				s, err = interpolate(s, options.interp)
+				if err != nil {
+					return nil, nil, err
+				}
+
 				if role != "" {
 					s = role + ": " + s
 					role = ""
@@ -129,6 +253,7 @@ func Parse(r io.Reader) (cmds []Command, err error) {
 
 				cmd.Args = s
 				cmds = append(cmds, cmd)
+				positions = append(positions, nameStart)
 			}
 
 			b.Reset()
@@ -137,19 +262,27 @@ func Parse(r io.Reader) (cmds []Command, err error) {
 
 		if strconv.IsPrint(r) {
 			if _, err := b.WriteRune(r); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 	}
 
 	// flush the buffer
 	switch curr {
-	case stateComment, stateNil:
+	case stateComment:
+		cmds = append(cmds, Command{Name: "comment", Args: b.String()})
+		positions = append(positions, commentStart)
+	case stateNil:
 		// pass; nothing to flush
 	case stateValue:
 		s, ok := unquote(b.String())
 		if !ok {
-			return nil, io.ErrUnexpectedEOF
+			return nil, nil, newParseError(name, pos, lineBuf.String(), KindUnexpectedEOF, io.ErrUnexpectedEOF, io.ErrUnexpectedEOF.Error())
+		}
+
+		s, err = interpolate(s, options.interp)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		if role != "" {
@@ -158,17 +291,12 @@ func Parse(r io.Reader) (cmds []Command, err error) {
 
 		cmd.Args = s
 		cmds = append(cmds, cmd)
+		positions = append(positions, nameStart)
 	default:
-		return nil, io.ErrUnexpectedEOF
-	}
-
-	for _, cmd := range cmds {
-		if cmd.Name == "model" {
-			return cmds, nil
-		}
+		return nil, nil, newParseError(name, pos, lineBuf.String(), KindUnexpectedEOF, io.ErrUnexpectedEOF, io.ErrUnexpectedEOF.Error())
 	}
 
-	return nil, errMissingFrom
+	return cmds, positions, nil
 }
 
 func parseRuneForState(r rune, cs state) (state, rune, error) {
@@ -223,7 +351,7 @@ func parseRuneForState(r rune, cs state) (state, rune, error) {
 		case isNewline(r):
 			return stateNil, 0, nil
 		default:
-			return stateComment, 0, nil
+			return stateComment, r, nil
 		}
 	default:
 		return stateNil, 0, errors.New("")
@@ -289,7 +417,7 @@ func isValidMessageRole(role string) bool {
 
 func isValidCommand(cmd string) bool {
 	switch strings.ToLower(cmd) {
-	case "from", "license", "template", "system", "adapter", "parameter", "message":
+	case "from", "license", "template", "system", "adapter", "parameter", "message", "include":
 		return true
 	default:
 		return false
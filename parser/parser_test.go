@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseCommandWithNoValueIsParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("FROM llama2\nPARAMETER\n"))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError (got %v)", err, err)
+	}
+	if pe.Line != 2 {
+		t.Fatalf("Line = %d, want 2", pe.Line)
+	}
+}
+
+func TestParseKeepsComments(t *testing.T) {
+	cmds, err := Parse(strings.NewReader("# a license\nFROM llama2\n# trailing, no newline after"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Command{
+		{Name: "comment", Args: " a license"},
+		{Name: "model", Args: "llama2"},
+		{Name: "comment", Args: " trailing, no newline after"},
+	}
+	if len(cmds) != len(want) {
+		t.Fatalf("Parse() = %#v, want %#v", cmds, want)
+	}
+	for i := range want {
+		if cmds[i] != want[i] {
+			t.Fatalf("cmds[%d] = %#v, want %#v", i, cmds[i], want[i])
+		}
+	}
+}
+
+func TestFormatRoundTripsComments(t *testing.T) {
+	src := "# a license\nFROM llama2\n"
+
+	cmds, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if got := Format(cmds); got != src {
+		t.Fatalf("Format(Parse(src)) = %q, want %q", got, src)
+	}
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxIncludeDepth bounds how deeply INCLUDE directives may nest,
+// guarding against runaway chains even when Resolve keys happen to
+// dodge the cycle check.
+const maxIncludeDepth = 10
+
+var (
+	errIncludeUnresolved = errors.New("INCLUDE: no Resolver configured")
+	errIncludeCycle      = errors.New("INCLUDE: cycle detected")
+	errIncludeDepth      = errors.New("INCLUDE: max include depth exceeded")
+)
+
+// Resolver resolves an INCLUDE target into a readable fragment on
+// behalf of Parse, so callers can sandbox where includes may read
+// from — a repo root, an OCI layer — instead of the parser reaching
+// for the filesystem directly.
+type Resolver interface {
+	// Resolve returns a Reader for ref as included from the file
+	// identified by from (empty for the root file being parsed), and
+	// a key that uniquely identifies the resolved fragment. key is
+	// used for cycle detection, so it must be stable for the same
+	// underlying content regardless of how ref was spelled.
+	Resolve(from, ref string) (r io.Reader, key string, err error)
+}
+
+// WithResolver enables INCLUDE directives, resolving each target
+// through resolver. Without this option, a Parse that encounters an
+// INCLUDE fails rather than silently ignoring it.
+func WithResolver(resolver Resolver) ParseOption {
+	return func(o *parseOptions) {
+		o.resolver = resolver
+	}
+}
+
+// FileResolver resolves INCLUDE targets against the local filesystem,
+// relative to the including file's directory. If Root is set, it
+// refuses to resolve any path that would escape it.
+type FileResolver struct {
+	Root string
+}
+
+func (fr FileResolver) Resolve(from, ref string) (io.Reader, string, error) {
+	dir := fr.Root
+	if from != "" {
+		dir = filepath.Dir(from)
+	}
+
+	path := filepath.Join(dir, ref)
+
+	if fr.Root != "" {
+		rel, err := filepath.Rel(fr.Root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, "", fmt.Errorf("include %q escapes root %q", ref, fr.Root)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return f, path, nil
+}
+
+// expandIncludes walks cmds, splicing in the commands of every
+// INCLUDE directive it finds. self identifies the file cmds came
+// from (for relative resolution), positions[i] is cmds[i]'s source
+// position (see parseWithOptions) so a failure can be reported at the
+// offending INCLUDE line, stack holds the keys of every file already
+// being included (for cycle detection), and depth bounds recursion.
+// FROM may only appear in the root file: an INCLUDEd fragment that
+// contributes its own FROM (directly or via a further nested INCLUDE)
+// is rejected, so the transitive closure can never end up with more
+// than the one FROM checkSingleFrom already validated at the root.
+func expandIncludes(cmds []Command, positions []Position, self string, options *parseOptions, stack []string, depth int) ([]Command, error) {
+	var hasInclude bool
+	for _, cmd := range cmds {
+		if cmd.Name == "include" {
+			hasInclude = true
+			break
+		}
+	}
+	if !hasInclude {
+		return cmds, nil
+	}
+
+	var out []Command
+	for i, cmd := range cmds {
+		if cmd.Name != "include" {
+			out = append(out, cmd)
+			continue
+		}
+
+		pos := positions[i]
+
+		if options.resolver == nil {
+			return nil, newParseError(self, pos, "", KindIncludeUnresolved, errIncludeUnresolved, errIncludeUnresolved.Error())
+		}
+
+		if depth >= maxIncludeDepth {
+			msg := fmt.Sprintf("INCLUDE %q: max depth of %d exceeded", cmd.Args, maxIncludeDepth)
+			return nil, newParseError(self, pos, "", KindIncludeDepth, errIncludeDepth, msg)
+		}
+
+		r, key, err := options.resolver.Resolve(self, cmd.Args)
+		if err != nil {
+			msg := fmt.Sprintf("INCLUDE %q: %s", cmd.Args, err)
+			return nil, newParseError(self, pos, "", KindIncludeError, err, msg)
+		}
+
+		for _, seen := range stack {
+			if seen == key {
+				if c, ok := r.(io.Closer); ok {
+					c.Close()
+				}
+				msg := fmt.Sprintf("INCLUDE %q: cycle detected (%s already being included)", cmd.Args, key)
+				return nil, newParseError(self, pos, "", KindIncludeCycle, errIncludeCycle, msg)
+			}
+		}
+
+		included, includedPositions, err := parseWithOptions(key, r, options)
+		if c, ok := r.(io.Closer); ok {
+			c.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		included, err = expandIncludes(included, includedPositions, key, options, append(stack, key), depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range included {
+			if c.Name == "model" {
+				msg := fmt.Sprintf("INCLUDE %q: included files may not contain their own FROM line (FROM must be declared in the root file)", cmd.Args)
+				return nil, newParseError(self, pos, "", KindDuplicateFrom, errDuplicateFrom, msg)
+			}
+		}
+
+		out = append(out, included...)
+	}
+
+	return out, nil
+}
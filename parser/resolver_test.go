@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// mapResolver resolves INCLUDE targets against an in-memory map, keyed
+// by the ref string itself, for testing without the filesystem.
+type mapResolver map[string]string
+
+func (m mapResolver) Resolve(from, ref string) (io.Reader, string, error) {
+	src, ok := m[ref]
+	if !ok {
+		return nil, "", errors.New("not found")
+	}
+	return strings.NewReader(src), ref, nil
+}
+
+func TestIncludeExpandsFragment(t *testing.T) {
+	resolver := mapResolver{
+		"shared.modelfile": "PARAMETER temperature 0.7\n",
+	}
+
+	cmds, err := Parse(strings.NewReader("FROM llama2\nINCLUDE \"shared.modelfile\"\n"), WithResolver(resolver))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var sawParam bool
+	for _, cmd := range cmds {
+		if cmd.Name == "temperature" && cmd.Args == "0.7" {
+			sawParam = true
+		}
+	}
+	if !sawParam {
+		t.Fatalf("expected an expanded temperature parameter, got %#v", cmds)
+	}
+}
+
+func TestIncludeWithoutResolverIsParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("FROM llama2\nINCLUDE \"shared.modelfile\"\n"))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError", err)
+	}
+	if pe.Kind != KindIncludeUnresolved {
+		t.Fatalf("Kind = %v, want KindIncludeUnresolved", pe.Kind)
+	}
+	if pe.Line != 2 {
+		t.Fatalf("Line = %d, want 2", pe.Line)
+	}
+}
+
+func TestIncludeCycleIsParseError(t *testing.T) {
+	resolver := mapResolver{
+		"a.modelfile": "FROM llama2\nINCLUDE \"b.modelfile\"\n",
+		"b.modelfile": "INCLUDE \"a.modelfile\"\n",
+	}
+
+	_, err := ParseFile("a.modelfile", strings.NewReader(resolver["a.modelfile"]), WithResolver(resolver))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError", err)
+	}
+	if pe.Kind != KindIncludeCycle {
+		t.Fatalf("Kind = %v, want KindIncludeCycle", pe.Kind)
+	}
+}
+
+func TestIncludeMaxDepthIsParseError(t *testing.T) {
+	resolver := mapResolver{}
+	for i := 0; i < maxIncludeDepth+2; i++ {
+		resolver[key(i)] = "INCLUDE \"" + key(i+1) + "\"\n"
+	}
+
+	_, err := Parse(strings.NewReader("FROM llama2\nINCLUDE \""+key(0)+"\"\n"), WithResolver(resolver))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError", err)
+	}
+	if pe.Kind != KindIncludeDepth {
+		t.Fatalf("Kind = %v, want KindIncludeDepth", pe.Kind)
+	}
+}
+
+func key(i int) string {
+	return "frag" + string(rune('a'+i%26)) + ".modelfile"
+}
+
+func TestIncludeWithOwnFromIsParseError(t *testing.T) {
+	resolver := mapResolver{
+		"shared.modelfile": "FROM mistral\n",
+	}
+
+	_, err := Parse(strings.NewReader("FROM llama2\nINCLUDE \"shared.modelfile\"\n"), WithResolver(resolver))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError", err)
+	}
+	if pe.Kind != KindDuplicateFrom {
+		t.Fatalf("Kind = %v, want KindDuplicateFrom", pe.Kind)
+	}
+	if pe.Line != 2 {
+		t.Fatalf("Line = %d, want 2 (the INCLUDE line)", pe.Line)
+	}
+}
+
+func TestIncludeOnlyFromIsParseError(t *testing.T) {
+	resolver := mapResolver{
+		"shared.modelfile": "FROM mistral\n",
+	}
+
+	_, err := Parse(strings.NewReader("INCLUDE \"shared.modelfile\"\n"), WithResolver(resolver))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError", err)
+	}
+	if pe.Kind != KindDuplicateFrom {
+		t.Fatalf("Kind = %v, want KindDuplicateFrom (FROM must come from the root file, not an INCLUDE)", pe.Kind)
+	}
+}
+
+func TestDuplicateFromInRootIsParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("FROM llama2\nFROM mistral\n"))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error is %T, want *ParseError", err)
+	}
+	if pe.Kind != KindDuplicateFrom {
+		t.Fatalf("Kind = %v, want KindDuplicateFrom", pe.Kind)
+	}
+	if pe.Line != 2 {
+		t.Fatalf("Line = %d, want 2", pe.Line)
+	}
+}